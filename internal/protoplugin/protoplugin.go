@@ -0,0 +1,90 @@
+// Package protoplugin provides validation helpers for the protoc plugins in
+// this repository. A protoc plugin reads a plugin.CodeGeneratorRequest from
+// protoc and must hand back a plugin.CodeGeneratorResponse that protoc can
+// trust; the checks here catch malformed input and malformed output before
+// they reach protoc. They are not specific to any one plugin; today
+// protoc-gen-swagger (through genswagger/plugin) is the only caller.
+package protoplugin
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+// ValidateRequest checks that req is safe to generate from. It rejects
+// requests that ask to generate zero files, name a file that isn't present
+// in req.ProtoFile, name a file more than once, or use a file name that is an
+// absolute path or contains ".." anywhere (not only as a path segment).
+func ValidateRequest(req *plugin.CodeGeneratorRequest) error {
+	if len(req.GetFileToGenerate()) == 0 {
+		return fmt.Errorf("no files to generate")
+	}
+
+	known := make(map[string]bool, len(req.GetProtoFile()))
+	for _, f := range req.GetProtoFile() {
+		known[f.GetName()] = true
+	}
+
+	seen := make(map[string]bool, len(req.GetFileToGenerate()))
+	for _, name := range req.GetFileToGenerate() {
+		if path.IsAbs(name) {
+			return fmt.Errorf("file to generate %q must not be an absolute path", name)
+		}
+		if strings.Contains(name, "..") {
+			return fmt.Errorf("file to generate %q must not contain \"..\"", name)
+		}
+		if !known[name] {
+			return fmt.Errorf("file to generate %q is not in the request's proto_file", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("file to generate %q was requested more than once", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// ValidateResponse checks that resp is safe to hand back to protoc. Every
+// file must have a name; no two host files (InsertionPoint == "") may share
+// a name, though any number of insertion-point files may target the same
+// host; every insertion point must target a host file name that was also
+// generated; and file content must be valid UTF-8.
+func ValidateResponse(resp *plugin.CodeGeneratorResponse) error {
+	hosts := make(map[string]bool, len(resp.GetFile()))
+	for _, f := range resp.GetFile() {
+		if f.GetInsertionPoint() == "" {
+			hosts[f.GetName()] = true
+		}
+	}
+
+	// Only host files (InsertionPoint == "") need a unique Name: a file may
+	// legitimately receive any number of insertion-point entries from
+	// different plugins or Modules, each targeting the same host Name at a
+	// different InsertionPoint.
+	seenHost := make(map[string]bool, len(resp.GetFile()))
+	for _, f := range resp.GetFile() {
+		name := f.GetName()
+		if name == "" {
+			return fmt.Errorf("response file has no name")
+		}
+
+		ip := f.GetInsertionPoint()
+		if ip == "" {
+			if seenHost[name] {
+				return fmt.Errorf("response file %q was generated more than once", name)
+			}
+			seenHost[name] = true
+		} else if !hosts[name] {
+			return fmt.Errorf("insertion point %q targets %q, which was not generated", ip, name)
+		}
+
+		if !utf8.ValidString(f.GetContent()) {
+			return fmt.Errorf("response file %q content is not valid UTF-8", name)
+		}
+	}
+	return nil
+}
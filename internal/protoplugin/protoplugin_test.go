@@ -0,0 +1,155 @@
+package protoplugin
+
+import (
+	"testing"
+
+	descriptorpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+func TestValidateRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *plugin.CodeGeneratorRequest
+		wantErr bool
+	}{
+		{
+			name:    "no files to generate",
+			req:     &plugin.CodeGeneratorRequest{},
+			wantErr: true,
+		},
+		{
+			name: "file to generate not in proto_file",
+			req: &plugin.CodeGeneratorRequest{
+				FileToGenerate: []string{"foo.proto"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "absolute path",
+			req: &plugin.CodeGeneratorRequest{
+				FileToGenerate: []string{"/foo.proto"},
+				ProtoFile: []*descriptorpb.FileDescriptorProto{
+					{Name: strPtr("/foo.proto")},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "dot-dot path segment",
+			req: &plugin.CodeGeneratorRequest{
+				FileToGenerate: []string{"../foo.proto"},
+				ProtoFile: []*descriptorpb.FileDescriptorProto{
+					{Name: strPtr("../foo.proto")},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate file to generate",
+			req: &plugin.CodeGeneratorRequest{
+				FileToGenerate: []string{"foo.proto", "foo.proto"},
+				ProtoFile: []*descriptorpb.FileDescriptorProto{
+					{Name: strPtr("foo.proto")},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid request",
+			req: &plugin.CodeGeneratorRequest{
+				FileToGenerate: []string{"foo.proto"},
+				ProtoFile: []*descriptorpb.FileDescriptorProto{
+					{Name: strPtr("foo.proto")},
+				},
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateRequest(tc.req)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ValidateRequest() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    *plugin.CodeGeneratorResponse
+		wantErr bool
+	}{
+		{
+			name: "unnamed file",
+			resp: &plugin.CodeGeneratorResponse{
+				File: []*plugin.CodeGeneratorResponse_File{
+					{Content: strPtr("x")},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate host file",
+			resp: &plugin.CodeGeneratorResponse{
+				File: []*plugin.CodeGeneratorResponse_File{
+					{Name: strPtr("foo.go"), Content: strPtr("a")},
+					{Name: strPtr("foo.go"), Content: strPtr("b")},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "insertion point without a host file",
+			resp: &plugin.CodeGeneratorResponse{
+				File: []*plugin.CodeGeneratorResponse_File{
+					{Name: strPtr("foo.go"), InsertionPoint: strPtr("imports"), Content: strPtr("x")},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid UTF-8 content",
+			resp: &plugin.CodeGeneratorResponse{
+				File: []*plugin.CodeGeneratorResponse_File{
+					{Name: strPtr("foo.go"), Content: strPtr("\xff\xfe")},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "multiple insertion points into the same host file",
+			resp: &plugin.CodeGeneratorResponse{
+				File: []*plugin.CodeGeneratorResponse_File{
+					{Name: strPtr("foo.go"), Content: strPtr("package foo")},
+					{Name: strPtr("foo.go"), InsertionPoint: strPtr("imports"), Content: strPtr(`"a"`)},
+					{Name: strPtr("foo.go"), InsertionPoint: strPtr("interface_methods"), Content: strPtr("Bar()")},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid response",
+			resp: &plugin.CodeGeneratorResponse{
+				File: []*plugin.CodeGeneratorResponse_File{
+					{Name: strPtr("foo.swagger.json"), Content: strPtr("{}")},
+				},
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateResponse(tc.resp)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ValidateResponse() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func strPtr(s string) *string { return &s }
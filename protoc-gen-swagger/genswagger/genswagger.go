@@ -0,0 +1,298 @@
+// Package genswagger generates a swagger (OpenAPI 2.0) document from a set
+// of annotated proto descriptor.Files.
+package genswagger
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	descriptorpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+
+	"github.com/gengo/grpc-gateway/protoc-gen-grpc-gateway/descriptor"
+	"github.com/golang/protobuf/proto"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+// Generator turns a set of descriptor.Files into swagger
+// CodeGeneratorResponse_Files, partitioning the output according to splitBy.
+type Generator struct {
+	reg           *descriptor.Registry
+	splitBy       string
+	mergeFileName string
+}
+
+// New returns a Generator for reg. splitBy controls how the generated
+// swagger docs are partitioned:
+//
+//	"file"    - one doc per source .proto (the default, one-to-one with the
+//	            previous behavior of this package)
+//	"service" - one doc per gRPC service, containing only the paths that
+//	            service declares and the message definitions its methods
+//	            reference
+//	"package" - one merged doc per proto package, named mergeFileName
+//	"tag"     - currently an alias for "service": this package does not yet
+//	            read per-method swagger tag annotations, so it cannot
+//	            partition by tag independently of service. Pick "tag" only
+//	            if that equivalence is acceptable; the -split_by flag help
+//	            text spells this out.
+//
+// Within a partition, Definitions and SecurityDefinitions from every
+// contributing file are merged, de-duplicating by fully-qualified type name
+// (first file to define a name wins), and Paths are merged by their URL
+// template.
+func New(reg *descriptor.Registry, splitBy, mergeFileName string) *Generator {
+	return &Generator{reg: reg, splitBy: splitBy, mergeFileName: mergeFileName}
+}
+
+// Document is the mutable, pre-serialization form of one partition's
+// swagger content. It is exported so callers that need to contribute to a
+// partition before it's serialized (see genswagger/plugin.Module) can merge
+// into it directly instead of only appending whole sidecar files.
+type Document struct {
+	Definitions         map[string]interface{}
+	Paths               map[string]interface{}
+	SecurityDefinitions map[string]interface{}
+}
+
+// NewDocument returns an empty Document.
+func NewDocument() *Document {
+	return &Document{
+		Definitions:         map[string]interface{}{},
+		Paths:               map[string]interface{}{},
+		SecurityDefinitions: map[string]interface{}{},
+	}
+}
+
+// Merge folds src into doc. Definitions and SecurityDefinitions are
+// de-duplicated by key, so the first contributor to define a given
+// fully-qualified name wins; Paths are merged by URL template.
+func (doc *Document) Merge(src *Document) {
+	for name, def := range src.Definitions {
+		if _, ok := doc.Definitions[name]; !ok {
+			doc.Definitions[name] = def
+		}
+	}
+	for p, item := range src.Paths {
+		doc.Paths[p] = item
+	}
+	for name, sd := range src.SecurityDefinitions {
+		if _, ok := doc.SecurityDefinitions[name]; !ok {
+			doc.SecurityDefinitions[name] = sd
+		}
+	}
+}
+
+// Partition is one named group of target files whose merged Document
+// becomes a single CodeGeneratorResponse_File once marshaled.
+type Partition struct {
+	// name identifies the partition; OutputName derives this partition's
+	// CodeGeneratorResponse_File name from it.
+	name string
+	// Doc is this partition's merged, pre-serialization swagger content.
+	// Modules may merge additional fragments into it before it's marshaled.
+	Doc *Document
+}
+
+// Partitions groups targets into g's partitions, building and merging each
+// target file's contribution into every partition it belongs to. Unlike
+// Generate, it stops short of marshaling, so callers (notably
+// genswagger/plugin.Module) can still merge fragments into a Partition's Doc
+// first.
+func (g *Generator) Partitions(targets []*descriptor.File) ([]*Partition, error) {
+	byName := make(map[string]*Document)
+	var order []string
+
+	add := func(name string, src *Document) {
+		doc, ok := byName[name]
+		if !ok {
+			doc = NewDocument()
+			byName[name] = doc
+			order = append(order, name)
+		}
+		doc.Merge(src)
+	}
+
+	for _, f := range targets {
+		switch g.splitBy {
+		case "package":
+			add(packagePartitionName(f), buildFileDoc(f))
+		case "service", "tag":
+			if len(f.GetService()) == 0 {
+				// A file with no service (e.g. one that only declares
+				// shared messages) has nowhere else to go: fold it into
+				// its own per-file partition.
+				add(filePartitionName(f), buildFileDoc(f))
+				continue
+			}
+			for _, svc := range f.GetService() {
+				add(servicePartitionName(f, svc), buildServiceDoc(f, svc))
+			}
+		default: // "file"
+			add(filePartitionName(f), buildFileDoc(f))
+		}
+	}
+
+	sort.Strings(order)
+	out := make([]*Partition, 0, len(order))
+	for _, name := range order {
+		out = append(out, &Partition{name: name, Doc: byName[name]})
+	}
+	return out, nil
+}
+
+// Generate builds g's partitions and marshals each to a
+// CodeGeneratorResponse_File. Callers that need a Module to merge fragments
+// into a partition's Doc before marshaling should call Partitions and
+// Marshal directly instead; see genswagger/plugin.Module.
+func (g *Generator) Generate(targets []*descriptor.File) ([]*plugin.CodeGeneratorResponse_File, error) {
+	parts, err := g.Partitions(targets)
+	if err != nil {
+		return nil, err
+	}
+	return Marshal(parts, g.mergeFileName)
+}
+
+// Marshal renders parts (as returned by Generator.Partitions, after any
+// Module fragments have been merged into them) into one
+// CodeGeneratorResponse_File per partition.
+func Marshal(parts []*Partition, mergeFileName string) ([]*plugin.CodeGeneratorResponse_File, error) {
+	out := make([]*plugin.CodeGeneratorResponse_File, 0, len(parts))
+	for _, p := range parts {
+		content, err := marshalDocument(p.Doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", p.name, err)
+		}
+		out = append(out, &plugin.CodeGeneratorResponse_File{
+			Name:    proto.String(OutputName(p.name, mergeFileName)),
+			Content: proto.String(content),
+		})
+	}
+	return out, nil
+}
+
+func filePartitionName(f *descriptor.File) string {
+	return "file:" + f.GetName()
+}
+
+func packagePartitionName(f *descriptor.File) string {
+	pkg := f.GetPackage()
+	if pkg == "" {
+		pkg = "_"
+	}
+	return "package:" + pkg
+}
+
+func servicePartitionName(f *descriptor.File, svc *descriptorpb.ServiceDescriptorProto) string {
+	return "service:" + fqn(f.GetPackage(), svc.GetName())
+}
+
+// OutputName derives a CodeGeneratorResponse_File name for a partition name
+// produced by Generator.Partitions.
+func OutputName(name, mergeFileName string) string {
+	spec := strings.SplitN(name, ":", 2)
+	kind, rest := spec[0], spec[1]
+	switch kind {
+	case "package":
+		if rest == "_" {
+			return mergeFileName + ".swagger.json"
+		}
+		return path.Join(strings.ReplaceAll(rest, ".", "/"), mergeFileName+".swagger.json")
+	case "service":
+		// rest is a fqn like ".pkg.Foo"; fqn's leading "." would otherwise
+		// turn into a leading "/" here.
+		return strings.TrimPrefix(strings.ReplaceAll(rest, ".", "/"), "/") + ".swagger.json"
+	default: // "file"
+		return strings.TrimSuffix(rest, path.Ext(rest)) + ".swagger.json"
+	}
+}
+
+// buildFileDoc builds the Document contributed by a whole file: one
+// Definitions entry per message it declares, and one Paths entry per method
+// of every service it declares. Used for split_by=file/package, where a
+// partition is expected to contain everything a file declares.
+func buildFileDoc(f *descriptor.File) *Document {
+	doc := NewDocument()
+	for _, msg := range f.GetMessageType() {
+		doc.Definitions[fqn(f.GetPackage(), msg.GetName())] = messageSchema()
+	}
+	for _, svc := range f.GetService() {
+		doc.Merge(buildServiceDoc(f, svc))
+	}
+	return doc
+}
+
+// buildServiceDoc builds the Document contributed by a single service: one
+// Paths entry per method, plus only the message definitions those methods
+// actually reference (not every message f happens to declare), so that
+// split_by=service/tag partitions stay isolated to their own service
+// instead of leaking every other service in the same file.
+func buildServiceDoc(f *descriptor.File, svc *descriptorpb.ServiceDescriptorProto) *Document {
+	doc := NewDocument()
+
+	referenced := make(map[string]bool, len(svc.GetMethod())*2)
+	for _, m := range svc.GetMethod() {
+		referenced[m.GetInputType()] = true
+		referenced[m.GetOutputType()] = true
+
+		doc.Paths[fmt.Sprintf("/%s/%s", svc.GetName(), m.GetName())] = map[string]interface{}{
+			"post": map[string]interface{}{
+				"operationId": svc.GetName() + "_" + m.GetName(),
+				"tags":        []string{svc.GetName()},
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "A successful response.",
+						"schema": map[string]interface{}{
+							"$ref": "#/definitions/" + m.GetOutputType(),
+						},
+					},
+				},
+			},
+		}
+	}
+
+	for _, msg := range f.GetMessageType() {
+		name := fqn(f.GetPackage(), msg.GetName())
+		if referenced[name] {
+			doc.Definitions[name] = messageSchema()
+		}
+	}
+
+	return doc
+}
+
+func messageSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "object"}
+}
+
+// fqn returns the fully-qualified, dot-separated name of a type named name
+// declared in pkg, matching the format protoc uses for
+// FieldDescriptorProto.type_name and friends (e.g. ".pkg.Message").
+func fqn(pkg, name string) string {
+	if pkg == "" {
+		return "." + name
+	}
+	return "." + pkg + "." + name
+}
+
+// marshalDocument renders doc as a swagger 2.0 JSON document.
+func marshalDocument(doc *Document) (string, error) {
+	out := map[string]interface{}{
+		"swagger": "2.0",
+		"paths":   doc.Paths,
+	}
+	if len(doc.Definitions) > 0 {
+		out["definitions"] = doc.Definitions
+	}
+	if len(doc.SecurityDefinitions) > 0 {
+		out["securityDefinitions"] = doc.SecurityDefinitions
+	}
+
+	buf, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal swagger document: %v", err)
+	}
+	return string(buf), nil
+}
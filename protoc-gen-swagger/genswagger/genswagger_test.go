@@ -0,0 +1,178 @@
+package genswagger
+
+import (
+	"encoding/json"
+	"testing"
+
+	descriptorpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+
+	"github.com/gengo/grpc-gateway/protoc-gen-grpc-gateway/descriptor"
+)
+
+func strPtr(s string) *string { return &s }
+
+func newFile(name, pkg string, msgNames []string, svcs []*descriptorpb.ServiceDescriptorProto) *descriptor.File {
+	msgs := make([]*descriptorpb.DescriptorProto, len(msgNames))
+	for i, n := range msgNames {
+		msgs[i] = &descriptorpb.DescriptorProto{Name: strPtr(n)}
+	}
+	return &descriptor.File{
+		FileDescriptorProto: &descriptorpb.FileDescriptorProto{
+			Name:        strPtr(name),
+			Package:     strPtr(pkg),
+			MessageType: msgs,
+			Service:     svcs,
+		},
+	}
+}
+
+func method(name, in, out string) *descriptorpb.MethodDescriptorProto {
+	return &descriptorpb.MethodDescriptorProto{
+		Name:       strPtr(name),
+		InputType:  strPtr(in),
+		OutputType: strPtr(out),
+	}
+}
+
+// TestGenerate_SplitByServiceIsolatesServices checks that a file with two
+// services yields one doc per service holding only that service's paths,
+// rather than a whole-file doc (see buildFileDoc) merged into every
+// service's partition.
+func TestGenerate_SplitByServiceIsolatesServices(t *testing.T) {
+	f := newFile("foo.proto", "pkg", []string{"FooReq", "FooResp", "BarReq", "BarResp"}, []*descriptorpb.ServiceDescriptorProto{
+		{
+			Name:   strPtr("Foo"),
+			Method: []*descriptorpb.MethodDescriptorProto{method("Get", ".pkg.FooReq", ".pkg.FooResp")},
+		},
+		{
+			Name:   strPtr("Bar"),
+			Method: []*descriptorpb.MethodDescriptorProto{method("Get", ".pkg.BarReq", ".pkg.BarResp")},
+		},
+	})
+
+	g := New(nil, "service", "apidocs")
+	out, err := g.Generate([]*descriptor.File{f})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if len(out) != 2 {
+		t.Fatalf("Generate() produced %d files, want 2", len(out))
+	}
+
+	for _, o := range out {
+		var doc map[string]interface{}
+		if err := json.Unmarshal([]byte(o.GetContent()), &doc); err != nil {
+			t.Fatalf("invalid JSON in %s: %v", o.GetName(), err)
+		}
+		paths, _ := doc["paths"].(map[string]interface{})
+
+		switch o.GetName() {
+		case "pkg/Foo.swagger.json":
+			if _, ok := paths["/Foo/Get"]; !ok {
+				t.Errorf("%s missing /Foo/Get", o.GetName())
+			}
+			if _, ok := paths["/Bar/Get"]; ok {
+				t.Errorf("%s leaked /Bar/Get from the other service", o.GetName())
+			}
+		case "pkg/Bar.swagger.json":
+			if _, ok := paths["/Bar/Get"]; !ok {
+				t.Errorf("%s missing /Bar/Get", o.GetName())
+			}
+			if _, ok := paths["/Foo/Get"]; ok {
+				t.Errorf("%s leaked /Foo/Get from the other service", o.GetName())
+			}
+		default:
+			t.Errorf("unexpected output file %s", o.GetName())
+		}
+	}
+}
+
+// TestGenerate_SplitByServiceFiltersDefinitions checks that a per-service
+// partition only carries the message definitions its own methods reference,
+// not every message declared in the source file.
+func TestGenerate_SplitByServiceFiltersDefinitions(t *testing.T) {
+	f := newFile("foo.proto", "pkg", []string{"FooReq", "FooResp", "BarReq", "BarResp"}, []*descriptorpb.ServiceDescriptorProto{
+		{
+			Name:   strPtr("Foo"),
+			Method: []*descriptorpb.MethodDescriptorProto{method("Get", ".pkg.FooReq", ".pkg.FooResp")},
+		},
+		{
+			Name:   strPtr("Bar"),
+			Method: []*descriptorpb.MethodDescriptorProto{method("Get", ".pkg.BarReq", ".pkg.BarResp")},
+		},
+	})
+
+	g := New(nil, "service", "apidocs")
+	out, err := g.Generate([]*descriptor.File{f})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	for _, o := range out {
+		var doc map[string]interface{}
+		if err := json.Unmarshal([]byte(o.GetContent()), &doc); err != nil {
+			t.Fatalf("invalid JSON in %s: %v", o.GetName(), err)
+		}
+		defs, _ := doc["definitions"].(map[string]interface{})
+
+		if o.GetName() == "pkg/Foo.swagger.json" {
+			if _, ok := defs[".pkg.BarReq"]; ok {
+				t.Errorf("%s leaked .pkg.BarReq definition from the other service", o.GetName())
+			}
+		}
+	}
+}
+
+func TestGenerate_SplitByFile(t *testing.T) {
+	f := newFile("foo.proto", "pkg", []string{"FooReq", "FooResp"}, []*descriptorpb.ServiceDescriptorProto{
+		{
+			Name:   strPtr("Foo"),
+			Method: []*descriptorpb.MethodDescriptorProto{method("Get", ".pkg.FooReq", ".pkg.FooResp")},
+		},
+	})
+
+	g := New(nil, "file", "apidocs")
+	out, err := g.Generate([]*descriptor.File{f})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if len(out) != 1 || out[0].GetName() != "foo.swagger.json" {
+		t.Fatalf("Generate() = %v, want a single foo.swagger.json", out)
+	}
+}
+
+func TestDocumentMerge_DedupesDefinitions(t *testing.T) {
+	doc := NewDocument()
+	doc.Definitions[".pkg.Foo"] = map[string]interface{}{"type": "object", "from": "first"}
+
+	other := NewDocument()
+	other.Definitions[".pkg.Foo"] = map[string]interface{}{"type": "object", "from": "second"}
+	other.Paths["/Foo/Get"] = map[string]interface{}{}
+
+	doc.Merge(other)
+
+	if got := doc.Definitions[".pkg.Foo"].(map[string]interface{})["from"]; got != "first" {
+		t.Errorf("Merge() overwrote an existing definition, got from=%v, want first", got)
+	}
+	if _, ok := doc.Paths["/Foo/Get"]; !ok {
+		t.Errorf("Merge() did not carry over the new path")
+	}
+}
+
+func TestOutputName(t *testing.T) {
+	tests := []struct {
+		name          string
+		mergeFileName string
+		want          string
+	}{
+		{"file:foo/bar.proto", "apidocs", "foo/bar.swagger.json"},
+		{"service:.pkg.Foo", "apidocs", "pkg/Foo.swagger.json"},
+		{"package:pkg", "apidocs", "pkg/apidocs.swagger.json"},
+		{"package:_", "apidocs", "apidocs.swagger.json"},
+	}
+	for _, tc := range tests {
+		if got := OutputName(tc.name, tc.mergeFileName); got != tc.want {
+			t.Errorf("OutputName(%q, %q) = %q, want %q", tc.name, tc.mergeFileName, got, tc.want)
+		}
+	}
+}
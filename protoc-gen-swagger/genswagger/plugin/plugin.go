@@ -0,0 +1,321 @@
+// Package plugin provides a small protoc-gen-star style entrypoint around
+// genswagger, so that users can extend protoc-gen-swagger in-process (to add
+// auth schemes, vendor extensions, or entirely new sidecar files) without
+// forking it. It plays the same role for protoc-gen-swagger that protogen.Run
+// plays for protoc-gen-go.
+package plugin
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gengo/grpc-gateway/internal/protoplugin"
+	"github.com/gengo/grpc-gateway/protoc-gen-grpc-gateway/descriptor"
+	"github.com/gengo/grpc-gateway/protoc-gen-swagger/genswagger"
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	plugin_go "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+var (
+	importPrefix  = flag.String("import_prefix", "", "prefix to be added to go package paths for imported proto files")
+	file          = flag.String("file", "stdin", `where to read the CodeGeneratorRequest from: "stdin" (default), a path ending in ".pb" to read a serialized request, or a path ending in ".json" to read a JSON-encoded one`)
+	responseOut   = flag.String("response_out", "", "write the CodeGeneratorResponse to this path instead of stdout")
+	dumpRequest   = flag.String("dump_request", "", "write the CodeGeneratorRequest this run received to this path as a serialized .pb, so it can be replayed later with -file")
+	splitBy       = flag.String("split_by", "file", "criterion by which to partition the generated swagger docs: file, service, package, or tag (tag is currently an alias for service; see genswagger.New)")
+	mergeFileName = flag.String("merge_file_name", "apidocs", "name to use for each package's merged swagger doc when split_by=package, whether the package has one file or several")
+	paths         = flag.String("paths", "", `how to compute swagger output file paths; "source_relative" (default) emits foo/bar.swagger.json next to foo/bar.proto, "import" emits it under the proto's go_package import path, matching protoc-gen-go's "paths" parameter`)
+)
+
+// TODO(swagger): advertise
+// plugin_go.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL via
+// CodeGeneratorResponse.SupportedFeatures once genswagger provably handles
+// proto3 optional fields (it currently doesn't special-case them, so it may
+// still rely on the synthetic oneof protoc synthesizes for a "optional"
+// field today). Advertising the feature before then would tell protoc to
+// stop synthesizing that oneof and make optionality undetectable here.
+
+// validSplitBy are the values accepted by the split_by flag.
+var validSplitBy = map[string]bool{
+	"file":    true,
+	"service": true,
+	"package": true,
+	"tag":     true,
+}
+
+// Module lets callers extend a swagger Generator without forking
+// protoc-gen-swagger. Every registered Module is invoked once per swagger
+// partition the base Generator produces (see genswagger.Generator.Partitions),
+// in registration order, before that partition is marshaled.
+type Module interface {
+	// Name identifies the module in log messages and generation errors.
+	Name() string
+	// Augment is called once per partition produced by the base Generator,
+	// before it's marshaled to JSON. It may merge fragments -- additional
+	// paths, definitions, security schemes, vendor extensions -- directly
+	// into doc, and/or return additional CodeGeneratorResponse_File entries
+	// (e.g. an AsyncAPI sidecar) to emit alongside whatever genswagger
+	// produces for doc.
+	Augment(reg *descriptor.Registry, doc *genswagger.Document) ([]*plugin_go.CodeGeneratorResponse_File, error)
+}
+
+// Plugin carries the state of a single protoc-gen-swagger invocation: the
+// descriptor.Registry loaded from the CodeGeneratorRequest, the request
+// itself, and whatever Modules have been registered so far.
+type Plugin struct {
+	// Registry is the descriptor.Registry loaded from Request. Modules use
+	// it to resolve cross-file type references.
+	Registry *descriptor.Registry
+	// Request is the CodeGeneratorRequest protoc sent to this plugin.
+	Request *plugin_go.CodeGeneratorRequest
+
+	modules []Module
+}
+
+// RegisterModule adds m to the set of Modules consulted while generating
+// output. Modules run in the order they were registered.
+func (p *Plugin) RegisterModule(m Module) {
+	p.modules = append(p.modules, m)
+}
+
+// Run parses a CodeGeneratorRequest from stdin (or from -file, see loadReq),
+// builds a Plugin from it, and calls f so it can RegisterModule whatever it
+// needs. It then runs the base genswagger Generator plus every registered
+// Module, validates the result, and emits a CodeGeneratorResponse to stdout
+// (or to -response_out, see emitResp).
+//
+// A plugin with no extensions of its own is just:
+//
+//	func main() {
+//		plugin.Run(func(p *plugin.Plugin) error { return nil })
+//	}
+func Run(f func(*Plugin) error) {
+	flag.Parse()
+	defer glog.Flush()
+
+	glog.V(1).Info("Processing code generator request")
+	req, err := loadReq()
+	if err != nil {
+		glog.Fatal(err)
+	}
+	if *dumpRequest != "" {
+		buf, err := proto.Marshal(req)
+		if err != nil {
+			glog.Fatalf("failed to marshal request for -dump_request: %v", err)
+		}
+		if err := ioutil.WriteFile(*dumpRequest, buf, 0644); err != nil {
+			glog.Fatalf("failed to write -dump_request file %s: %v", *dumpRequest, err)
+		}
+	}
+	if err := protoplugin.ValidateRequest(req); err != nil {
+		emitError(err)
+		return
+	}
+
+	reg := descriptor.NewRegistry()
+	if req.Parameter != nil {
+		for _, p := range strings.Split(req.GetParameter(), ",") {
+			spec := strings.SplitN(p, "=", 2)
+			if len(spec) == 1 {
+				if err := flag.CommandLine.Set(spec[0], ""); err != nil {
+					glog.Fatalf("Cannot set flag %s", p)
+				}
+				continue
+			}
+			name, value := spec[0], spec[1]
+			if strings.HasPrefix(name, "M") {
+				reg.AddPkgMap(name[1:], value)
+				continue
+			}
+			if err := flag.CommandLine.Set(name, value); err != nil {
+				glog.Fatalf("Cannot set flag %s", p)
+			}
+		}
+	}
+	reg.SetPrefix(*importPrefix)
+	if err := reg.Load(req); err != nil {
+		emitError(err)
+		return
+	}
+
+	p := &Plugin{Registry: reg, Request: req}
+	if err := f(p); err != nil {
+		emitError(err)
+		return
+	}
+
+	var targets []*descriptor.File
+	for _, name := range req.FileToGenerate {
+		file, err := reg.LookupFile(name)
+		if err != nil {
+			glog.Fatal(err)
+		}
+		targets = append(targets, file)
+	}
+
+	if !validSplitBy[*splitBy] {
+		glog.Fatalf("invalid split_by %q: must be one of file, service, package, tag", *splitBy)
+	}
+	if *paths != "" && *paths != "source_relative" && *paths != "import" {
+		glog.Fatalf(`invalid paths %q: must be "", "source_relative", or "import"`, *paths)
+	}
+	g := genswagger.New(reg, *splitBy, *mergeFileName)
+	parts, err := g.Partitions(targets)
+	if err != nil {
+		emitError(err)
+		return
+	}
+
+	var extra []*plugin_go.CodeGeneratorResponse_File
+	for _, m := range p.modules {
+		for _, part := range parts {
+			files, err := m.Augment(reg, part.Doc)
+			if err != nil {
+				glog.Fatalf("module %s: %v", m.Name(), err)
+			}
+			extra = append(extra, files...)
+		}
+	}
+
+	out, err := genswagger.Marshal(parts, *mergeFileName)
+	if err != nil {
+		emitError(err)
+		return
+	}
+	out = append(out, extra...)
+	rewriteOutputPaths(out, targets, *paths)
+	glog.V(1).Info("Processed code generator request")
+
+	resp := &plugin_go.CodeGeneratorResponse{File: out}
+	if err := protoplugin.ValidateResponse(resp); err != nil {
+		emitError(err)
+		return
+	}
+	emitResp(resp)
+}
+
+// rewriteOutputPaths adjusts the Name of each file in out according to
+// paths: "import" moves the file under the go_package import path of the
+// *descriptor.File it was generated from (mirroring protoc-gen-go's
+// paths=import), while "" and "source_relative" leave genswagger's default
+// naming (next to the source .proto) untouched. Under "import" a file is
+// moved only if its name, minus ".swagger.json" or its extension, equals a
+// target .proto's name minus ".proto" and that target has a non-empty go
+// package path; other files, such as split_by=service or split_by=package
+// output, keep their names.
+func rewriteOutputPaths(out []*plugin_go.CodeGeneratorResponse_File, targets []*descriptor.File, paths string) {
+	if paths != "import" {
+		return
+	}
+
+	byStem := make(map[string]*descriptor.File, len(targets))
+	for _, f := range targets {
+		byStem[stem(f.GetName())] = f
+	}
+
+	for _, o := range out {
+		target, ok := byStem[stem(o.GetName())]
+		if !ok || target.GoPkg.Path == "" {
+			continue
+		}
+		o.Name = proto.String(path.Join(target.GoPkg.Path, path.Base(o.GetName())))
+	}
+}
+
+// swaggerExt is the compound extension genswagger appends to every output
+// file name (see genswagger.OutputName). path.Ext only ever returns the
+// final "." segment, which would leave the ".swagger" half behind and stop
+// a swagger output's stem from ever matching its source .proto's stem.
+const swaggerExt = ".swagger.json"
+
+// stem returns name with its extension removed, e.g. "foo/bar.proto" and
+// "foo/bar.swagger.json" both become "foo/bar".
+func stem(name string) string {
+	if strings.HasSuffix(name, swaggerExt) {
+		return strings.TrimSuffix(name, swaggerExt)
+	}
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+// loadReq reads a CodeGeneratorRequest from wherever -file points: stdin by
+// default, a serialized .pb file, or a JSON-encoded .json file. The .json
+// form exists for reproducible fixtures and unit-testable plugin runs, where
+// a request is captured once (see -dump_request) and replayed offline
+// without protoc in the loop.
+func loadReq() (*plugin_go.CodeGeneratorRequest, error) {
+	switch {
+	case *file == "stdin":
+		return parseReq(os.Stdin)
+	case strings.HasSuffix(*file, ".json"):
+		return parseReqJSON(*file)
+	default:
+		return parseReqPB(*file)
+	}
+}
+
+func parseReq(r io.Reader) (*plugin_go.CodeGeneratorRequest, error) {
+	glog.V(1).Info("Parsing code generator request")
+	input, err := ioutil.ReadAll(r)
+	if err != nil {
+		glog.Errorf("Failed to read code generator request: %v", err)
+		return nil, err
+	}
+	req := new(plugin_go.CodeGeneratorRequest)
+	if err = proto.Unmarshal(input, req); err != nil {
+		glog.Errorf("Failed to unmarshal code generator request: %v", err)
+		return nil, err
+	}
+	glog.V(1).Info("Parsed code generator request")
+	return req, nil
+}
+
+func parseReqPB(filePath string) (*plugin_go.CodeGeneratorRequest, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open -file %s: %v", filePath, err)
+	}
+	defer f.Close()
+	return parseReq(f)
+}
+
+func parseReqJSON(filePath string) (*plugin_go.CodeGeneratorRequest, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open -file %s: %v", filePath, err)
+	}
+	defer f.Close()
+
+	req := new(plugin_go.CodeGeneratorRequest)
+	if err := jsonpb.Unmarshal(f, req); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal -file %s: %v", filePath, err)
+	}
+	return req, nil
+}
+
+func emitError(err error) {
+	emitResp(&plugin_go.CodeGeneratorResponse{Error: proto.String(err.Error())})
+}
+
+// emitResp writes the marshaled response to -response_out if set, or to
+// os.Stdout otherwise (the normal protoc plugin protocol).
+func emitResp(resp *plugin_go.CodeGeneratorResponse) {
+	buf, err := proto.Marshal(resp)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	if *responseOut != "" {
+		if err := ioutil.WriteFile(*responseOut, buf, 0644); err != nil {
+			glog.Fatal(err)
+		}
+		return
+	}
+	if _, err := os.Stdout.Write(buf); err != nil {
+		glog.Fatal(err)
+	}
+}
@@ -0,0 +1,135 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	descriptorpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin_go "github.com/golang/protobuf/protoc-gen-go/plugin"
+
+	"github.com/gengo/grpc-gateway/protoc-gen-grpc-gateway/descriptor"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestStem(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"foo/bar.proto", "foo/bar"},
+		{"foo/bar.swagger.json", "foo/bar"},
+		{"bar", "bar"},
+	}
+	for _, tc := range tests {
+		if got := stem(tc.name); got != tc.want {
+			t.Errorf("stem(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestRewriteOutputPaths_Import is a regression test: stem used to strip
+// only the final extension, so "foo/bar.swagger.json" stemmed to
+// "foo/bar.swagger" while "foo/bar.proto" stemmed to "foo/bar" -- the stems
+// never matched, so paths=import silently renamed nothing.
+func TestRewriteOutputPaths_Import(t *testing.T) {
+	targets := []*descriptor.File{
+		{
+			FileDescriptorProto: &descriptorpb.FileDescriptorProto{Name: strPtr("foo/bar.proto")},
+			GoPkg:               descriptor.GoPackage{Path: "example.com/foo"},
+		},
+	}
+	out := []*plugin_go.CodeGeneratorResponse_File{
+		{Name: strPtr("foo/bar.swagger.json"), Content: strPtr("{}")},
+	}
+
+	rewriteOutputPaths(out, targets, "import")
+
+	want := "example.com/foo/bar.swagger.json"
+	if got := out[0].GetName(); got != want {
+		t.Errorf("rewriteOutputPaths() renamed to %q, want %q", got, want)
+	}
+}
+
+func TestRewriteOutputPaths_SourceRelativeLeavesNameAlone(t *testing.T) {
+	targets := []*descriptor.File{
+		{
+			FileDescriptorProto: &descriptorpb.FileDescriptorProto{Name: strPtr("foo/bar.proto")},
+			GoPkg:               descriptor.GoPackage{Path: "example.com/foo"},
+		},
+	}
+	out := []*plugin_go.CodeGeneratorResponse_File{
+		{Name: strPtr("foo/bar.swagger.json"), Content: strPtr("{}")},
+	}
+
+	rewriteOutputPaths(out, targets, "source_relative")
+
+	if got := out[0].GetName(); got != "foo/bar.swagger.json" {
+		t.Errorf("rewriteOutputPaths() changed name to %q, want it untouched", got)
+	}
+}
+
+// TestLoadReq_JSONFile and TestEmitResp_ResponseOut exercise the -file=*.json
+// and -response_out paths of loadReq and emitResp, which exist for
+// reproducible fixtures and unit-testable plugin runs.
+func TestLoadReq_JSONFile(t *testing.T) {
+	req := &plugin_go.CodeGeneratorRequest{
+		FileToGenerate: []string{"foo.proto"},
+		ProtoFile: []*descriptorpb.FileDescriptorProto{
+			{Name: strPtr("foo.proto")},
+		},
+	}
+
+	dir := t.TempDir()
+	reqPath := filepath.Join(dir, "req.json")
+	f, err := os.Create(reqPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (&jsonpb.Marshaler{}).Marshal(f, req); err != nil {
+		f.Close()
+		t.Fatal(err)
+	}
+	f.Close()
+
+	prevFile := *file
+	*file = reqPath
+	defer func() { *file = prevFile }()
+
+	got, err := loadReq()
+	if err != nil {
+		t.Fatalf("loadReq() error = %v", err)
+	}
+	if !proto.Equal(got, req) {
+		t.Errorf("loadReq() = %v, want %v", got, req)
+	}
+}
+
+func TestEmitResp_ResponseOut(t *testing.T) {
+	resp := &plugin_go.CodeGeneratorResponse{
+		File: []*plugin_go.CodeGeneratorResponse_File{
+			{Name: strPtr("foo.swagger.json"), Content: strPtr("{}")},
+		},
+	}
+
+	dir := t.TempDir()
+	outPath := filepath.Join(dir, "resp.pb")
+
+	prevOut := *responseOut
+	*responseOut = outPath
+	defer func() { *responseOut = prevOut }()
+
+	emitResp(resp)
+
+	buf, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("reading -response_out file: %v", err)
+	}
+	got := &plugin_go.CodeGeneratorResponse{}
+	if err := proto.Unmarshal(buf, got); err != nil {
+		t.Fatalf("unmarshaling -response_out content: %v", err)
+	}
+	if !proto.Equal(got, resp) {
+		t.Errorf("emitResp() wrote %v, want %v", got, resp)
+	}
+}
@@ -1,43 +1,6 @@
-package main
-
-import (
-	"flag"
-	"io"
-	"io/ioutil"
-	"os"
-	"strings"
-
-	"github.com/gengo/grpc-gateway/protoc-gen-grpc-gateway/descriptor"
-	"github.com/gengo/grpc-gateway/protoc-gen-swagger/genswagger"
-	"github.com/golang/glog"
-	"github.com/golang/protobuf/proto"
-	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
-)
-
-var (
-	importPrefix = flag.String("import_prefix", "", "prefix to be added to go package paths for imported proto files")
-	file         = flag.String("file", "stdin", "where to load data from")
-)
-
-func parseReq(r io.Reader) (*plugin.CodeGeneratorRequest, error) {
-	glog.V(1).Info("Parsing code generator request")
-	input, err := ioutil.ReadAll(r)
-	if err != nil {
-		glog.Errorf("Failed to read code generator request: %v", err)
-		return nil, err
-	}
-	req := new(plugin.CodeGeneratorRequest)
-	if err = proto.Unmarshal(input, req); err != nil {
-		glog.Errorf("Failed to unmarshal code generator request: %v", err)
-		return nil, err
-	}
-	glog.V(1).Info("Parsed code generator request")
-	return req, nil
-}
-
-// Main function of the protobuf compiler (protoc) plugin for generating a
-// swagger spec from an appropriately annotated protobuf definition file (a
-// file with extention `*.proto`).
+// Command protoc-gen-swagger is a protobuf compiler (protoc) plugin for
+// generating a swagger spec from an appropriately annotated protobuf
+// definition file (a file with extension `*.proto`).
 //
 // In rough terms this is how a protoc plugin works.
 //
@@ -83,82 +46,19 @@ func parseReq(r io.Reader) (*plugin.CodeGeneratorRequest, error) {
 // plugin. However, all plugins that I know of do leverage the protobuf
 // libraries for their language to be able to parse the byte serialized AST
 // passed in from the main `protoc`.
-func main() {
-	flag.Parse()
-	defer glog.Flush()
-
-	reg := descriptor.NewRegistry()
-
-	glog.V(1).Info("Processing code generator request")
-	f := os.Stdin
-	if *file != "stdin" {
-		f, _ = os.Open("input.txt")
-	}
-	req, err := parseReq(f)
-	if err != nil {
-		glog.Fatal(err)
-	}
-	if req.Parameter != nil {
-		for _, p := range strings.Split(req.GetParameter(), ",") {
-			spec := strings.SplitN(p, "=", 2)
-			if len(spec) == 1 {
-				if err := flag.CommandLine.Set(spec[0], ""); err != nil {
-					glog.Fatalf("Cannot set flag %s", p)
-				}
-				continue
-			}
-			name, value := spec[0], spec[1]
-			if strings.HasPrefix(name, "M") {
-				reg.AddPkgMap(name[1:], value)
-				continue
-			}
-			if err := flag.CommandLine.Set(name, value); err != nil {
-				glog.Fatalf("Cannot set flag %s", p)
-			}
-		}
-	}
-
-	g := genswagger.New(reg)
-
-	reg.SetPrefix(*importPrefix)
-	if err := reg.Load(req); err != nil {
-		emitError(err)
-		return
-	}
-
-	var targets []*descriptor.File
-	for _, target := range req.FileToGenerate {
-		f, err := reg.LookupFile(target)
-		if err != nil {
-			glog.Fatal(err)
-		}
-		targets = append(targets, f)
-	}
-
-	out, err := g.Generate(targets)
-	glog.V(1).Info("Processed code generator request")
-	if err != nil {
-		emitError(err)
-		return
-	}
-	emitFiles(out)
-}
-
-func emitFiles(out []*plugin.CodeGeneratorResponse_File) {
-	emitResp(&plugin.CodeGeneratorResponse{File: out})
-}
+//
+// This binary is now just a thin wrapper around genswagger/plugin, which
+// does the actual request handling; it exists so that users who want to
+// extend protoc-gen-swagger in-process can depend on genswagger/plugin
+// directly and register their own Modules instead of forking this file.
+package main
 
-func emitError(err error) {
-	emitResp(&plugin.CodeGeneratorResponse{Error: proto.String(err.Error())})
-}
+import (
+	"github.com/gengo/grpc-gateway/protoc-gen-swagger/genswagger/plugin"
+)
 
-// Write the marshaled output of the provided response to os.Stdout
-func emitResp(resp *plugin.CodeGeneratorResponse) {
-	buf, err := proto.Marshal(resp)
-	if err != nil {
-		glog.Fatal(err)
-	}
-	if _, err := os.Stdout.Write(buf); err != nil {
-		glog.Fatal(err)
-	}
+func main() {
+	plugin.Run(func(p *plugin.Plugin) error {
+		return nil
+	})
 }